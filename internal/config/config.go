@@ -0,0 +1,104 @@
+// Copyright 2014 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+// Package config 提供对 logs 包所使用的 XML 配置文件的解析功能。
+package config
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"strings"
+)
+
+// Item 表示配置文件中的一个 XML 元素节点。
+type Item struct {
+	Attrs map[string]string  // 该元素的属性列表
+	Items map[string][]*Item // 子元素，以元素名称分类
+	Order []string           // 子元素在配置文件中出现的顺序，元素名称可重复
+}
+
+// Config 为解析 XML 配置文件之后得到的结果，Items 以日志级别
+// 或其它顶级元素的名称为键，Formats 以 <format> 元素的 id 属性为键，
+// 值为该元素的文本内容，供日志级别元素的 format 属性引用。
+type Config struct {
+	Items   map[string]*Item
+	Formats map[string]string
+}
+
+// node 用于从 XML 中读取出一棵通用的元素树，
+// 之后再转换成 Item 实例。
+type node struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Nodes    []node     `xml:",any"`
+	CharData string     `xml:",chardata"`
+}
+
+func (n *node) toItem() *Item {
+	item := &Item{
+		Attrs: make(map[string]string, len(n.Attrs)),
+		Items: make(map[string][]*Item, len(n.Nodes)),
+	}
+
+	for _, attr := range n.Attrs {
+		item.Attrs[attr.Name.Local] = attr.Value
+	}
+
+	for index := range n.Nodes {
+		child := n.Nodes[index].toItem()
+		name := n.Nodes[index].XMLName.Local
+		item.Items[name] = append(item.Items[name], child)
+		item.Order = append(item.Order, name)
+	}
+
+	return item
+}
+
+// ParseXMLFile 从指定的文件中解析出配置内容。
+func ParseXMLFile(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseXMLBytes(data)
+}
+
+// ParseXMLString 从 XML 格式的字符串中解析出配置内容。
+func ParseXMLString(str string) (*Config, error) {
+	return ParseXMLBytes([]byte(str))
+}
+
+// ParseXMLBytes 从 XML 格式的内容中解析出配置内容。
+func ParseXMLBytes(data []byte) (*Config, error) {
+	root := &node{}
+	if err := xml.Unmarshal(data, root); err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{
+		Items:   make(map[string]*Item, len(root.Nodes)),
+		Formats: make(map[string]string, len(root.Nodes)),
+	}
+	for index := range root.Nodes {
+		n := &root.Nodes[index]
+		name := n.XMLName.Local
+
+		if name == "format" {
+			id := ""
+			for _, attr := range n.Attrs {
+				if attr.Name.Local == "id" {
+					id = attr.Value
+					break
+				}
+			}
+			cfg.Formats[id] = strings.TrimSpace(n.CharData)
+			continue
+		}
+
+		cfg.Items[name] = n.toItem()
+	}
+
+	return cfg, nil
+}
@@ -5,11 +5,18 @@
 package logs
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
 	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/issue9/logs/internal/config"
 	"github.com/issue9/logs/writers"
@@ -37,18 +44,70 @@ var levels = map[string]int{
 
 var loggers [levelSize]*logger
 
+// levelMask 是一个位掩码，第 n 位表示级别 n 是否处于启用状态，
+// 通过原子操作读写，使得 Info、Debug 等函数在级别被禁用时，
+// 只需一次原子读取即可判断是否需要放弃本次输出，而不必构造
+// 一条最终被 ioutil.Discard 丢弃的记录。
+var levelMask uint32 = 1<<levelSize - 1 // 默认所有级别都启用
+
+// enabled 判断指定级别当前是否处于启用状态。
+func enabled(level int) bool {
+	return atomic.LoadUint32(&levelMask)&(1<<uint(level)) != 0
+}
+
+// SetLevel 只启用 level 及其后（即数值更大）的级别，级别数值更小的将被禁用，
+// 与 minlevel/maxlevel 的区间语义保持一致。
+func SetLevel(level int) {
+	var mask uint32
+	for i := level; i < levelSize; i++ {
+		mask |= 1 << uint(i)
+	}
+	atomic.StoreUint32(&levelMask, mask)
+}
+
+// Disable 是一个全局开关，调用之后 Info、Infof、InfoCtx 等各级别的日志函数
+// 都将直接返回而不输出；All、Fatal、Panic 系列函数以及通过 INFO() 等函数
+// 取得的 log.Logger 不受其影响。
+func Disable() {
+	atomic.StoreUint32(&levelMask, 0)
+}
+
+// Enable 重新启用所有级别的日志输出，用于撤销 Disable 的效果。
+func Enable() {
+	atomic.StoreUint32(&levelMask, 1<<levelSize-1)
+}
+
 type logger struct {
-	flush writers.Flusher
-	log   *log.Logger // 要确保这些值不能为空，因为要保证对应的 ERROR() 等函数的返回值是始终可用的。
+	flush     writers.Flusher
+	log       *log.Logger // 要确保这些值不能为空，因为要保证对应的 ERROR() 等函数的返回值是始终可用的。
+	formatter *writers.Formatter
+	async     *writers.Async // 仅在启用 async 时不为空，供 AsyncStats 访问统计信息；未配置 format 时与 flush 指向同一个实例，配置了 format 时 flush 为 formatter。
 }
 
 func (l *logger) set(w io.Writer, prefix string, flag int) {
+	l.formatter = nil
+	l.async = nil
+	if a, ok := w.(*writers.Async); ok {
+		l.async = a
+	}
 	if f, ok := w.(writers.Flusher); ok {
 		l.flush = f
 	}
 	l.log = log.New(w, prefix, flag)
 }
 
+// output 是所有级别日志函数的统一出口：若该级别配置了 format，
+// 则交由 formatter 按自定义格式输出；否则沿用 log.Logger 原有的
+// Output 方式，由 flag 控制输出格式。ctx 为已经取值完毕的上下文前缀
+// （参考 ctxString），非 *Ctx 系列函数传递空字符串即可。
+func (l *logger) output(calldepth int, level, ctx, msg string) {
+	if l.formatter != nil {
+		l.formatter.Write(level, calldepth+1, ctx, msg)
+		return
+	}
+	l.log.Output(calldepth+1, ctx+msg)
+}
+
 func init() {
 	for index := range loggers {
 		loggers[index] = &logger{}
@@ -59,6 +118,9 @@ func init() {
 
 func setDefaultLog() {
 	for _, l := range loggers {
+		if l.async != nil {
+			l.async.Close() // 重新加载配置前关闭旧的异步写入 goroutine，避免泄漏。
+		}
 		l.set(ioutil.Discard, "", log.LstdFlags)
 	}
 }
@@ -105,17 +167,108 @@ func initFromConfig(cfg *config.Config) error {
 		if err != nil {
 			return err
 		}
-		index, found := levels[name]
-		if !found {
-			return fmt.Errorf("未知道的二级元素名称:[%v]", name)
+
+		if w, err = wrapAsync(w, c.Attrs); err != nil {
+			return err
+		}
+
+		minIdx, maxIdx, err := levelRange(name, c.Attrs)
+		if err != nil {
+			return err
+		}
+
+		var formatter *writers.Formatter
+		if id, found := c.Attrs["format"]; found && len(id) > 0 {
+			format, found := cfg.Formats[id]
+			if !found {
+				return fmt.Errorf("未找到 id 为 [%v] 的 format 元素", id)
+			}
+			if formatter, err = writers.NewFormatter(w, format); err != nil {
+				return err
+			}
 		}
 
-		loggers[index].set(w, c.Attrs["prefix"], flag)
+		for index := minIdx; index <= maxIdx; index++ {
+			loggers[index].set(w, c.Attrs["prefix"], flag)
+			if formatter != nil {
+				loggers[index].formatter = formatter
+				loggers[index].flush = formatter
+			}
+		}
 	}
 
 	return nil
 }
 
+// levelRange 计算元素 name 对应的日志级别区间。元素可以直接以某个级别
+// 命名（如 <warn>），也可以通过 minlevel/maxlevel 属性声明一个级别区间
+// （如 <file minlevel="warn">），此时所有介于两者之间的级别都使用同一个
+// 输出实例，与 seelog 的级别区间写法保持一致。
+func levelRange(name string, attrs map[string]string) (min, max int, err error) {
+	min, minFound := levels[name]
+	max = min
+
+	if v, found := attrs["minlevel"]; found && len(v) > 0 {
+		if min, found = levels[v]; !found {
+			return 0, 0, fmt.Errorf("未知的 minlevel 值：[%v]", v)
+		}
+		// 只声明了 minlevel 时，表示“该级别及其后”，max 默认取最高级别，
+		// 而不是退化成只接受 min 这一个级别；之后若显式声明了 maxlevel，
+		// 会在下面被覆盖。
+		max = LevelCritical
+		minFound = true
+	}
+
+	if v, found := attrs["maxlevel"]; found && len(v) > 0 {
+		if max, found = levels[v]; !found {
+			return 0, 0, fmt.Errorf("未知的 maxlevel 值：[%v]", v)
+		}
+		minFound = true
+	}
+
+	if !minFound {
+		return 0, 0, fmt.Errorf("未知道的二级元素名称:[%v]", name)
+	}
+	if min > max {
+		return 0, 0, fmt.Errorf("minlevel 不能大于 maxlevel")
+	}
+
+	return min, max, nil
+}
+
+// wrapAsync 根据 attrs 中的 async 系列属性，决定是否将 w 包装成异步模式。
+// async="true" 时开启，buffer 指定队列长度，overflow 指定队列写满之后的
+// 处理策略（block、drop-oldest 或 drop-newest），flush-interval 指定定时
+// 刷新底层 io.Writer 的间隔。
+func wrapAsync(w io.Writer, attrs map[string]string) (io.Writer, error) {
+	if attrs["async"] != "true" {
+		return w, nil
+	}
+
+	size := 0
+	if s, found := attrs["buffer"]; found && len(s) > 0 {
+		var err error
+		if size, err = strconv.Atoi(s); err != nil {
+			return nil, fmt.Errorf("async 的 buffer 参数无效：%v", err)
+		}
+	}
+
+	overflow := writers.OverflowBlock
+	if o, found := attrs["overflow"]; found && len(o) > 0 {
+		overflow = writers.Overflow(o)
+	}
+
+	var flushInterval time.Duration
+	if fi, found := attrs["flush-interval"]; found && len(fi) > 0 {
+		var err error
+		if flushInterval, err = time.ParseDuration(fi); err != nil {
+			return nil, fmt.Errorf("async 的 flush-interval 参数无效：%v", err)
+		}
+	}
+
+	return writers.NewAsync(w, size, overflow, flushInterval), nil
+}
+
 // Flush 输出所有的缓存内容。
 // 若是通过 os.Exit() 退出程序的，在执行之前，
 // 一定记得调用 Flush() 输出可能缓存的日志内容。
@@ -127,6 +280,64 @@ func Flush() {
 	}
 }
 
+var (
+	ctxKeysMu sync.RWMutex
+	ctxKeys   = map[string]interface{}{} // 输出名称 -> context.Context 中对应的键
+)
+
+// RegisterContextKey 注册一个需要从 context.Context 中提取并输出的键，
+// name 为输出日志时使用的名称（如 "trace-id"），key 为调用
+// context.Context.Value(key) 时使用的键。
+// 调用 InfoCtx、ErrorCtx 等函数时，会按 name 的字典序依次取出已注册
+// 的值并输出，未注册或取不到值的键将被忽略。
+func RegisterContextKey(name string, key interface{}) {
+	ctxKeysMu.Lock()
+	defer ctxKeysMu.Unlock()
+	ctxKeys[name] = key
+}
+
+// ctxString 在日志调用发生的那一刻，从 ctx 中取出所有已注册的值并
+// 拼接成形如 "{trace-id: abc123} " 的前缀。之所以在此处立即取值，
+// 而不是延迟到异步队列的写入 goroutine 中处理，是因为 ctx 可能在
+// 写入 goroutine 运行之前就已经被取消，届时将无法再取到期望的值。
+func ctxString(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	ctxKeysMu.RLock()
+	defer ctxKeysMu.RUnlock()
+	if len(ctxKeys) == 0 {
+		return ""
+	}
+
+	names := make([]string, 0, len(ctxKeys))
+	for name := range ctxKeys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	buf := new(bytes.Buffer)
+	for _, name := range names {
+		v := ctx.Value(ctxKeys[name])
+		if v == nil {
+			continue
+		}
+		fmt.Fprintf(buf, "{%v: %v} ", name, v)
+	}
+	return buf.String()
+}
+
+// AsyncStats 返回指定级别异步队列的统计信息：累计被丢弃的记录数量，
+// 以及当前队列中尚未写出的记录数量。若该级别未启用 async 模式，
+// 则两个返回值均为 0，调用方可据此判断是否需要告警。
+func AsyncStats(level int) (dropped uint64, depth int) {
+	if a := loggers[level].async; a != nil {
+		return a.Dropped(), a.QueueDepth()
+	}
+	return 0, 0
+}
+
 // INFO 获取 INFO 级别的 log.Logger 实例，在未指定 info 级别的日志时，该实例返回一个 nil。
 func INFO() *log.Logger {
 	return loggers[LevelInfo].log
@@ -136,12 +347,27 @@ func INFO() *log.Logger {
 // Info 函数默认是带换行符的，若需要不带换行符的，请使用 DEBUG().Print() 函数代替。
 // 其它相似函数也有类型功能。
 func Info(v ...interface{}) {
-	loggers[LevelInfo].log.Output(2, fmt.Sprintln(v...))
+	if !enabled(LevelInfo) {
+		return
+	}
+	loggers[LevelInfo].output(2, "info", "", fmt.Sprintln(v...))
 }
 
 // Infof 相当于 INFO().Printf(format, v...) 的简写方式
 func Infof(format string, v ...interface{}) {
-	loggers[LevelInfo].log.Output(2, fmt.Sprintf(format, v...))
+	if !enabled(LevelInfo) {
+		return
+	}
+	loggers[LevelInfo].output(2, "info", "", fmt.Sprintf(format, v...))
+}
+
+// InfoCtx 与 Info 功能相同，但会从 ctx 中取出已通过 RegisterContextKey
+// 注册的值并一并输出，例如 trace-id。
+func InfoCtx(ctx context.Context, v ...interface{}) {
+	if !enabled(LevelInfo) {
+		return
+	}
+	loggers[LevelInfo].output(2, "info", ctxString(ctx), fmt.Sprintln(v...))
 }
 
 // DEBUG 获取 DEBUG 级别的 log.Logger 实例，在未指定 debug 级别的日志时，该实例返回一个 nil。
@@ -151,12 +377,27 @@ func DEBUG() *log.Logger {
 
 // Debug 相当于 DEBUG().Println(v...) 的简写方式
 func Debug(v ...interface{}) {
-	loggers[LevelDebug].log.Output(2, fmt.Sprintln(v...))
+	if !enabled(LevelDebug) {
+		return
+	}
+	loggers[LevelDebug].output(2, "debug", "", fmt.Sprintln(v...))
 }
 
 // Debugf 相当于 DEBUG().Printf(format, v...) 的简写方式
 func Debugf(format string, v ...interface{}) {
-	loggers[LevelDebug].log.Output(2, fmt.Sprintf(format, v...))
+	if !enabled(LevelDebug) {
+		return
+	}
+	loggers[LevelDebug].output(2, "debug", "", fmt.Sprintf(format, v...))
+}
+
+// DebugCtx 与 Debug 功能相同，但会从 ctx 中取出已通过 RegisterContextKey
+// 注册的值并一并输出，例如 trace-id。
+func DebugCtx(ctx context.Context, v ...interface{}) {
+	if !enabled(LevelDebug) {
+		return
+	}
+	loggers[LevelDebug].output(2, "debug", ctxString(ctx), fmt.Sprintln(v...))
 }
 
 // TRACE 获取 TRACE 级别的 log.Logger 实例，在未指定 trace 级别的日志时，该实例返回一个 nil。
@@ -166,12 +407,27 @@ func TRACE() *log.Logger {
 
 // Trace 相当于 TRACE().Println(v...) 的简写方式
 func Trace(v ...interface{}) {
-	loggers[LevelTrace].log.Output(2, fmt.Sprintln(v...))
+	if !enabled(LevelTrace) {
+		return
+	}
+	loggers[LevelTrace].output(2, "trace", "", fmt.Sprintln(v...))
 }
 
 // Tracef 相当于 TRACE().Printf(format, v...) 的简写方式
 func Tracef(format string, v ...interface{}) {
-	loggers[LevelTrace].log.Output(2, fmt.Sprintf(format, v...))
+	if !enabled(LevelTrace) {
+		return
+	}
+	loggers[LevelTrace].output(2, "trace", "", fmt.Sprintf(format, v...))
+}
+
+// TraceCtx 与 Trace 功能相同，但会从 ctx 中取出已通过 RegisterContextKey
+// 注册的值并一并输出，例如 trace-id。
+func TraceCtx(ctx context.Context, v ...interface{}) {
+	if !enabled(LevelTrace) {
+		return
+	}
+	loggers[LevelTrace].output(2, "trace", ctxString(ctx), fmt.Sprintln(v...))
 }
 
 // WARN 获取 WARN 级别的 log.Logger 实例，在未指定 warn 级别的日志时，该实例返回一个 nil。
@@ -181,12 +437,27 @@ func WARN() *log.Logger {
 
 // Warn 相当于 WARN().Println(v...) 的简写方式
 func Warn(v ...interface{}) {
-	loggers[LevelWarn].log.Output(2, fmt.Sprintln(v...))
+	if !enabled(LevelWarn) {
+		return
+	}
+	loggers[LevelWarn].output(2, "warn", "", fmt.Sprintln(v...))
 }
 
 // Warnf 相当于 WARN().Printf(format, v...) 的简写方式
 func Warnf(format string, v ...interface{}) {
-	loggers[LevelWarn].log.Output(2, fmt.Sprintf(format, v...))
+	if !enabled(LevelWarn) {
+		return
+	}
+	loggers[LevelWarn].output(2, "warn", "", fmt.Sprintf(format, v...))
+}
+
+// WarnCtx 与 Warn 功能相同，但会从 ctx 中取出已通过 RegisterContextKey
+// 注册的值并一并输出，例如 trace-id。
+func WarnCtx(ctx context.Context, v ...interface{}) {
+	if !enabled(LevelWarn) {
+		return
+	}
+	loggers[LevelWarn].output(2, "warn", ctxString(ctx), fmt.Sprintln(v...))
 }
 
 // ERROR 获取 ERROR 级别的 log.Logger 实例，在未指定 error 级别的日志时，该实例返回一个 nil。
@@ -196,12 +467,27 @@ func ERROR() *log.Logger {
 
 // Error 相当于 ERROR().Println(v...) 的简写方式
 func Error(v ...interface{}) {
-	loggers[LevelError].log.Output(2, fmt.Sprintln(v...))
+	if !enabled(LevelError) {
+		return
+	}
+	loggers[LevelError].output(2, "error", "", fmt.Sprintln(v...))
 }
 
 // Errorf 相当于 ERROR().Printf(format, v...) 的简写方式
 func Errorf(format string, v ...interface{}) {
-	loggers[LevelError].log.Output(2, fmt.Sprintf(format, v...))
+	if !enabled(LevelError) {
+		return
+	}
+	loggers[LevelError].output(2, "error", "", fmt.Sprintf(format, v...))
+}
+
+// ErrorCtx 与 Error 功能相同，但会从 ctx 中取出已通过 RegisterContextKey
+// 注册的值并一并输出，例如 trace-id。
+func ErrorCtx(ctx context.Context, v ...interface{}) {
+	if !enabled(LevelError) {
+		return
+	}
+	loggers[LevelError].output(2, "error", ctxString(ctx), fmt.Sprintln(v...))
 }
 
 // CRITICAL 获取 CRITICAL 级别的 log.Logger 实例，在未指定 critical 级别的日志时，该实例返回一个 nil。
@@ -211,12 +497,27 @@ func CRITICAL() *log.Logger {
 
 // Critical 相当于 CRITICAL().Println(v...)的简写方式
 func Critical(v ...interface{}) {
-	loggers[LevelCritical].log.Output(2, fmt.Sprintln(v...))
+	if !enabled(LevelCritical) {
+		return
+	}
+	loggers[LevelCritical].output(2, "critical", "", fmt.Sprintln(v...))
 }
 
 // Criticalf 相当于 CRITICAL().Printf(format, v...) 的简写方式
 func Criticalf(format string, v ...interface{}) {
-	loggers[LevelCritical].log.Output(2, fmt.Sprintf(format, v...))
+	if !enabled(LevelCritical) {
+		return
+	}
+	loggers[LevelCritical].output(2, "critical", "", fmt.Sprintf(format, v...))
+}
+
+// CriticalCtx 与 Critical 功能相同，但会从 ctx 中取出已通过 RegisterContextKey
+// 注册的值并一并输出，例如 trace-id。
+func CriticalCtx(ctx context.Context, v ...interface{}) {
+	if !enabled(LevelCritical) {
+		return
+	}
+	loggers[LevelCritical].output(2, "critical", ctxString(ctx), fmt.Sprintln(v...))
 }
 
 // All 向所有的日志输出内容。
@@ -259,13 +560,15 @@ func Panicf(format string, v ...interface{}) {
 }
 
 func all(v ...interface{}) {
-	for _, l := range loggers {
-		l.log.Output(3, fmt.Sprintln(v...))
+	msg := fmt.Sprintln(v...)
+	for name, index := range levels {
+		loggers[index].output(3, name, "", msg)
 	}
 }
 
 func allf(format string, v ...interface{}) {
-	for _, l := range loggers {
-		l.log.Output(3, fmt.Sprintf(format, v...))
+	msg := fmt.Sprintf(format, v...)
+	for name, index := range levels {
+		loggers[index].output(3, name, "", msg)
 	}
 }
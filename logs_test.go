@@ -0,0 +1,88 @@
+// Copyright 2014 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package logs
+
+import "testing"
+
+func TestLevelRange(t *testing.T) {
+	// 直接以级别名称命名，区间退化为该级别自身。
+	min, max, err := levelRange("warn", map[string]string{})
+	if err != nil {
+		t.Fatalf("levelRange 返回了意外的错误：%v", err)
+	}
+	if min != LevelWarn || max != LevelWarn {
+		t.Fatalf("levelRange(\"warn\", nil) = %v, %v，期望 %v, %v", min, max, LevelWarn, LevelWarn)
+	}
+
+	// 只声明 minlevel，区间应从 minlevel 一直延伸到最高级别。
+	min, max, err = levelRange("file", map[string]string{"minlevel": "warn"})
+	if err != nil {
+		t.Fatalf("levelRange 返回了意外的错误：%v", err)
+	}
+	if min != LevelWarn || max != LevelCritical {
+		t.Fatalf("levelRange(minlevel=warn) = %v, %v，期望 %v, %v", min, max, LevelWarn, LevelCritical)
+	}
+
+	// 同时声明 minlevel 与 maxlevel，使用显式区间。
+	min, max, err = levelRange("file", map[string]string{"minlevel": "warn", "maxlevel": "error"})
+	if err != nil {
+		t.Fatalf("levelRange 返回了意外的错误：%v", err)
+	}
+	if min != LevelWarn || max != LevelError {
+		t.Fatalf("levelRange(minlevel=warn, maxlevel=error) = %v, %v，期望 %v, %v", min, max, LevelWarn, LevelError)
+	}
+
+	// minlevel 大于 maxlevel 时应报错。
+	if _, _, err = levelRange("file", map[string]string{"minlevel": "error", "maxlevel": "warn"}); err == nil {
+		t.Fatal("levelRange(minlevel=error, maxlevel=warn) 未返回预期的错误")
+	}
+
+	// 未知的级别名称且未声明 minlevel/maxlevel 时应报错。
+	if _, _, err = levelRange("unknown", map[string]string{}); err == nil {
+		t.Fatal("levelRange(\"unknown\", nil) 未返回预期的错误")
+	}
+}
+
+func TestSetLevelDisableEnable(t *testing.T) {
+	defer Enable() // 恢复默认状态，避免影响其它测试。
+
+	SetLevel(LevelWarn)
+	for level := LevelInfo; level < LevelWarn; level++ {
+		if enabled(level) {
+			t.Fatalf("SetLevel(LevelWarn) 之后，级别 %v 不应处于启用状态", level)
+		}
+	}
+	for level := LevelWarn; level < levelSize; level++ {
+		if !enabled(level) {
+			t.Fatalf("SetLevel(LevelWarn) 之后，级别 %v 应处于启用状态", level)
+		}
+	}
+
+	Disable()
+	for level := LevelInfo; level < levelSize; level++ {
+		if enabled(level) {
+			t.Fatalf("Disable() 之后，级别 %v 不应处于启用状态", level)
+		}
+	}
+
+	Enable()
+	for level := LevelInfo; level < levelSize; level++ {
+		if !enabled(level) {
+			t.Fatalf("Enable() 之后，级别 %v 应处于启用状态", level)
+		}
+	}
+}
+
+// BenchmarkDisabledInfo 衡量级别被禁用时 Info 的开销：应只有一次原子读取，
+// 而不会构造并写入一条最终被丢弃的记录。
+func BenchmarkDisabledInfo(b *testing.B) {
+	Disable()
+	defer Enable()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Info("hello", "world")
+	}
+}
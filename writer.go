@@ -0,0 +1,98 @@
+// Copyright 2014 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package logs
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/issue9/logs/internal/config"
+	"github.com/issue9/logs/writers"
+)
+
+// 配置文件中 flag 属性支持的值，与 log 包中的常量一一对应。
+var flagMap = map[string]int{
+	"Ldate":         log.Ldate,
+	"Ltime":         log.Ltime,
+	"Lmicroseconds": log.Lmicroseconds,
+	"Llongfile":     log.Llongfile,
+	"Lshortfile":    log.Lshortfile,
+	"LUTC":          log.LUTC,
+	"LstdFlags":     log.LstdFlags,
+}
+
+// parseFlag 将配置文件中以竖线分隔的 flag 属性值转换成 log 包能识别的值，
+// 比如 "Ldate|Ltime|Lshortfile"。
+func parseFlag(flagStr string) (int, error) {
+	flag := 0
+	for _, s := range strings.Split(flagStr, "|") {
+		s = strings.TrimSpace(s)
+		v, found := flagMap[s]
+		if !found {
+			return 0, fmt.Errorf("未知的 flag 值：[%v]", s)
+		}
+		flag |= v
+	}
+	return flag, nil
+}
+
+// toWriter 将 c 中的子元素转换成一个真正输出日志内容的 io.Writer 实例，
+// c 的子元素有且只能有一个。
+func toWriter(c *config.Item) (io.Writer, error) {
+	for name, items := range c.Items {
+		if len(items) == 0 {
+			continue
+		}
+		return buildWriter(name, items[0])
+	}
+
+	return nil, fmt.Errorf("该级别下未指定任何输出实例")
+}
+
+// buildWriter 根据元素名称 name 及其内容 item 构建一个对应的 io.Writer 实例。
+// console 和 file 是内置的基础类型，其它元素一律通过 writers.Register
+// 注册的 Factory 构建，buffer、smtp、rotate 也是以这种方式内置的，
+// 第三方可以用同样的方式注册自己的 receiver（如 kafka、syslog），
+// 无需修改本包代码。
+func buildWriter(name string, item *config.Item) (io.Writer, error) {
+	switch name {
+	case "console":
+		return writers.NewConsole(item.Attrs)
+	case "file":
+		return writers.NewFile(item.Attrs)
+	default:
+		factory, found := writers.Lookup(name)
+		if !found {
+			return nil, fmt.Errorf("未知的输出类型：[%v]", name)
+		}
+
+		children, err := buildChildren(item)
+		if err != nil {
+			return nil, err
+		}
+		return factory(item.Attrs, children)
+	}
+}
+
+// buildChildren 按配置文件中出现的顺序构建 item 的所有子元素，
+// 顺序依据 item.Order，而不是 item.Items（后者以元素名称分类，
+// 不同名称的兄弟节点之间的相对顺序无法从中恢复）。
+func buildChildren(item *config.Item) ([]writers.Writer, error) {
+	seen := make(map[string]int, len(item.Items))
+	children := make([]writers.Writer, 0, len(item.Order))
+	for _, name := range item.Order {
+		child := item.Items[name][seen[name]]
+		seen[name]++
+
+		w, err := buildWriter(name, child)
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, w)
+	}
+	return children, nil
+}
@@ -0,0 +1,202 @@
+// Copyright 2014 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package writers
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Overflow 定义了异步队列写满之后的处理方式。
+type Overflow string
+
+// 预定义的几种队列溢出策略。
+const (
+	// OverflowBlock 会阻塞调用方，直到队列腾出空间为止。
+	OverflowBlock Overflow = "block"
+
+	// OverflowDropOldest 丢弃队列中最旧的一条记录，为新记录腾出空间。
+	OverflowDropOldest Overflow = "drop-oldest"
+
+	// OverflowDropNewest 直接丢弃当前这一条新记录。
+	OverflowDropNewest Overflow = "drop-newest"
+)
+
+// asyncDrainTimeout 是 Flush 等待队列排空的最长时间，
+// 超过该时间将放弃等待，避免 Fatal、Panic 等退出路径永久阻塞。
+const asyncDrainTimeout = 5 * time.Second
+
+// Async 是对 io.Writer 的异步封装，日志内容先写入一个有长度限制的
+// channel，再由专门的 goroutine 将其写入真正的底层 io.Writer，
+// 以避免调用方被慢速的 I/O 操作阻塞。
+type Async struct {
+	w         io.Writer
+	overflow  Overflow
+	queue     chan []byte
+	done      chan struct{}
+	flushReq  chan chan struct{}
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+
+	dropped uint64 // 原子操作，记录被丢弃的记录数量
+}
+
+// NewAsync 声明一个 Async 实例，w 为真正写入数据的底层 io.Writer，
+// size 为队列的长度，overflow 指定队列写满之后的处理方式，
+// flushInterval 如果大于 0，则会定时调用底层 io.Writer 的 Flush（如果有的话）。
+func NewAsync(w io.Writer, size int, overflow Overflow, flushInterval time.Duration) *Async {
+	if size <= 0 {
+		size = 4096
+	}
+
+	a := &Async{
+		w:        w,
+		overflow: overflow,
+		queue:    make(chan []byte, size),
+		done:     make(chan struct{}),
+		flushReq: make(chan chan struct{}),
+	}
+
+	a.wg.Add(1)
+	go a.loop(flushInterval)
+
+	return a
+}
+
+func (a *Async) loop(flushInterval time.Duration) {
+	defer a.wg.Done()
+
+	var ticker *time.Ticker
+	var tickerC <-chan time.Time
+	if flushInterval > 0 {
+		ticker = time.NewTicker(flushInterval)
+		tickerC = ticker.C
+		defer ticker.Stop()
+	}
+
+	for {
+		select {
+		case bs, ok := <-a.queue:
+			if !ok {
+				return
+			}
+			a.w.Write(bs)
+		case <-tickerC:
+			a.flushUnderlying()
+		case done := <-a.flushReq:
+			// 排空当前已入队的内容，再通知 Flush 调用方，
+			// 全程只有本 goroutine 接触 a.w，避免与调用方并发写入。
+			a.drainQueue()
+			a.flushUnderlying()
+			close(done)
+		case <-a.done:
+			// 退出之前，先将队列中剩余的内容全部写完。
+			a.drainQueue()
+			return
+		}
+	}
+}
+
+// drainQueue 将队列中当前已有的内容写入底层 io.Writer，仅供 loop 调用。
+func (a *Async) drainQueue() {
+	for {
+		select {
+		case bs := <-a.queue:
+			a.w.Write(bs)
+		default:
+			return
+		}
+	}
+}
+
+func (a *Async) flushUnderlying() {
+	if f, ok := a.w.(Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Write 实现 io.Writer 接口，bs 的内容会被复制一份放入队列，
+// 调用方可以安全地复用传入的 bs。
+func (a *Async) Write(bs []byte) (int, error) {
+	record := make([]byte, len(bs))
+	copy(record, bs)
+
+	switch a.overflow {
+	case OverflowDropOldest:
+		select {
+		case a.queue <- record:
+		default:
+			select {
+			case <-a.queue:
+				atomic.AddUint64(&a.dropped, 1)
+			default:
+			}
+			select {
+			case a.queue <- record:
+			default:
+				atomic.AddUint64(&a.dropped, 1)
+			}
+		}
+	case OverflowDropNewest:
+		select {
+		case a.queue <- record:
+		default:
+			atomic.AddUint64(&a.dropped, 1)
+		}
+	default: // OverflowBlock
+		a.queue <- record
+	}
+
+	return len(bs), nil
+}
+
+// Dropped 返回因队列已满而被丢弃的记录数量。
+func (a *Async) Dropped() uint64 {
+	return atomic.LoadUint64(&a.dropped)
+}
+
+// QueueDepth 返回当前队列中尚未写出的记录数量。
+func (a *Async) QueueDepth() int {
+	return len(a.queue)
+}
+
+// Flush 等待队列排空并将内容写入底层 io.Writer，最长等待 asyncDrainTimeout，
+// 超时后放弃等待以避免调用方永久阻塞。
+//
+// 排空动作由 loop 所在的 goroutine 完成，Flush 只是发出请求并等待其完成，
+// 不会自己从队列中取出记录写入 a.w，避免与 loop 并发写入同一个底层
+// io.Writer（尤其是 bufio.Writer 这类本身不具备并发安全保证的实现）。
+func (a *Async) Flush() error {
+	timeout := time.After(asyncDrainTimeout)
+	done := make(chan struct{})
+
+	select {
+	case a.flushReq <- done:
+	case <-a.done: // loop 已经因为 Close 退出，无需再发请求。
+		return nil
+	case <-timeout:
+		return fmt.Errorf("等待异步队列排空超时，仍有 %v 条记录未输出", len(a.queue))
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-timeout:
+		return fmt.Errorf("等待异步队列排空超时，仍有 %v 条记录未输出", len(a.queue))
+	}
+}
+
+// Close 停止后台写入的 goroutine，并等待队列中剩余的内容写完。
+// 多次调用是安全的，只有第一次调用会真正生效。
+func (a *Async) Close() error {
+	a.closeOnce.Do(func() {
+		close(a.done)
+		a.wg.Wait()
+	})
+	return nil
+}
@@ -0,0 +1,43 @@
+// Copyright 2014 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package writers
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// buffer 是带缓存功能的 io.Writer 实现，在写入量达到指定大小之前，
+// 内容只存在于内存之中，调用 Flush 可强制输出。
+type buffer struct {
+	writer *bufio.Writer
+}
+
+// NewBuffer 根据配置内容，返回一个带缓存功能的 io.Writer 实例，
+// w 为实际写入数据的底层 io.Writer。
+// attrs["size"] 指定缓存区的大小，默认为 4096。
+func NewBuffer(w io.Writer, attrs map[string]string) (io.Writer, error) {
+	size := 4096
+	if s, found := attrs["size"]; found && len(s) > 0 {
+		var err error
+		size, err = strconv.Atoi(s)
+		if err != nil {
+			return nil, fmt.Errorf("buffer 的 size 参数无效：%v", err)
+		}
+	}
+
+	return &buffer{writer: bufio.NewWriterSize(w, size)}, nil
+}
+
+func (b *buffer) Write(bs []byte) (int, error) {
+	return b.writer.Write(bs)
+}
+
+// Flush 将缓存中的内容输出到底层的 io.Writer。
+func (b *buffer) Flush() error {
+	return b.writer.Flush()
+}
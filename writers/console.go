@@ -0,0 +1,24 @@
+// Copyright 2014 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package writers
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// NewConsole 根据配置内容，返回一个输出到标准输出或标准错误的 io.Writer 实例。
+// attrs["output"] 的取值为 stdout 或 stderr，默认为 stdout。
+func NewConsole(attrs map[string]string) (io.Writer, error) {
+	switch attrs["output"] {
+	case "", "stdout":
+		return os.Stdout, nil
+	case "stderr":
+		return os.Stderr, nil
+	default:
+		return nil, fmt.Errorf("console 不支持该 output 值：%v", attrs["output"])
+	}
+}
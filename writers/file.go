@@ -0,0 +1,22 @@
+// Copyright 2014 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package writers
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// NewFile 根据配置内容返回一个写入到本地文件的 io.Writer 实例。
+// attrs["path"] 指定了文件的路径，该值不能为空。
+func NewFile(attrs map[string]string) (io.Writer, error) {
+	path := attrs["path"]
+	if len(path) == 0 {
+		return nil, fmt.Errorf("file 缺少必要的参数：path")
+	}
+
+	return os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, os.ModePerm)
+}
@@ -0,0 +1,166 @@
+// Copyright 2014 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package writers
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// record 保存了一次输出所需的全部信息，由 verb 函数读取并写入最终的结果。
+type record struct {
+	level string
+	time  time.Time
+	file  string
+	line  int
+	pc    uintptr
+	ctx   string
+	msg   string
+}
+
+// verb 负责将 record 中的某一部分内容写入 buf。
+type verb func(buf *bytes.Buffer, r *record)
+
+// Formatter 根据预先定义好的格式字符串，将日志记录格式化之后输出到底层的
+// io.Writer，格式字符串在初始化时被解析成一组 verb 函数，避免每次输出日志
+// 时重新解析格式串所带来的内存分配。
+type Formatter struct {
+	w     io.Writer
+	verbs []verb
+}
+
+// NewFormatter 根据 format 解析出对应的 Formatter 实例，w 为真正写入
+// 数据的底层 io.Writer。
+//
+// format 的写法参考以下示例：
+//
+//	%Date %Time - [%Lev] - %RelFile - l%Line - %Msg%n
+func NewFormatter(w io.Writer, format string) (*Formatter, error) {
+	f := &Formatter{w: w}
+
+	raw := format
+	for len(raw) > 0 {
+		index := strings.IndexByte(raw, '%')
+		if index < 0 {
+			f.verbs = append(f.verbs, literalVerb(raw))
+			break
+		}
+
+		if index > 0 {
+			f.verbs = append(f.verbs, literalVerb(raw[:index]))
+		}
+		raw = raw[index+1:]
+
+		name, rest := verbName(raw)
+		v, found := verbs[name]
+		if !found {
+			// 无法识别的占位符，原样输出，不中断解析。
+			f.verbs = append(f.verbs, literalVerb("%"+name))
+		} else {
+			f.verbs = append(f.verbs, v)
+		}
+		raw = rest
+	}
+
+	return f, nil
+}
+
+// verbName 用最长匹配原则，从 raw 的起始位置找出一个已知的占位符名称，
+// 返回该名称以及剩余未解析的内容。
+func verbName(raw string) (name string, rest string) {
+	longest := ""
+	for n := range verbs {
+		if strings.HasPrefix(raw, n) && len(n) > len(longest) {
+			longest = n
+		}
+	}
+	if longest == "" {
+		if len(raw) == 0 {
+			return "", ""
+		}
+		return raw[:1], raw[1:]
+	}
+	return longest, raw[len(longest):]
+}
+
+var verbs = map[string]verb{
+	"%":         literalVerb("%"),
+	"n":         literalVerb("\n"),
+	"t":         literalVerb("\t"),
+	"Date":      func(buf *bytes.Buffer, r *record) { buf.WriteString(r.time.Format("2006-01-02")) },
+	"UTCDate":   func(buf *bytes.Buffer, r *record) { buf.WriteString(r.time.UTC().Format("2006-01-02")) },
+	"Time":      func(buf *bytes.Buffer, r *record) { buf.WriteString(r.time.Format("15:04:05")) },
+	"UTCTime":   func(buf *bytes.Buffer, r *record) { buf.WriteString(r.time.UTC().Format("15:04:05")) },
+	"Ms":        func(buf *bytes.Buffer, r *record) { buf.WriteString(strconv.Itoa(r.time.Nanosecond() / 1e6)) },
+	"LEV":       func(buf *bytes.Buffer, r *record) { buf.WriteString(strings.ToUpper(r.level)) },
+	"Lev":       func(buf *bytes.Buffer, r *record) { buf.WriteString(strings.Title(r.level)) },
+	"l":         func(buf *bytes.Buffer, r *record) { buf.WriteString(r.level) },
+	"File":      func(buf *bytes.Buffer, r *record) { buf.WriteString(r.file) },
+	"RelFile":   func(buf *bytes.Buffer, r *record) { buf.WriteString(filepath.Base(r.file)) },
+	"Line":      func(buf *bytes.Buffer, r *record) { buf.WriteString(strconv.Itoa(r.line)) },
+	"FuncShort": func(buf *bytes.Buffer, r *record) { buf.WriteString(funcShort(r.pc)) },
+	"Ctx":       func(buf *bytes.Buffer, r *record) { buf.WriteString(r.ctx) },
+	"Msg":       func(buf *bytes.Buffer, r *record) { buf.WriteString(r.msg) },
+}
+
+func literalVerb(s string) verb {
+	return func(buf *bytes.Buffer, r *record) { buf.WriteString(s) }
+}
+
+// funcShort 根据调用处的 pc 返回所在函数的简短名称（不含包路径），
+// 如 "pkg.(*T).Method" 中的 "Method"。pc 为 0 或无法解析时返回 "???"。
+func funcShort(pc uintptr) string {
+	fn := runtime.FuncForPC(pc)
+	if fn == nil {
+		return "???"
+	}
+
+	name := fn.Name()
+	if index := strings.LastIndexByte(name, '.'); index >= 0 {
+		name = name[index+1:]
+	}
+	return name
+}
+
+// Write 根据 calldepth 获取调用方的文件名和行号，结合 level、ctx 与 msg
+// 构成一条完整的 record，格式化之后写入底层的 io.Writer。
+func (f *Formatter) Write(level string, calldepth int, ctx, msg string) (int, error) {
+	pc, file, line, ok := runtime.Caller(calldepth)
+	if !ok {
+		pc = 0
+		file = "???"
+		line = 0
+	}
+
+	r := &record{
+		level: level,
+		time:  time.Now(),
+		file:  file,
+		line:  line,
+		pc:    pc,
+		ctx:   ctx,
+		msg:   msg,
+	}
+
+	buf := new(bytes.Buffer)
+	for _, v := range f.verbs {
+		v(buf, r)
+	}
+
+	return f.w.Write(buf.Bytes())
+}
+
+// Flush 若底层的 io.Writer 实现了 Flusher 接口，则调用其 Flush 方法。
+func (f *Formatter) Flush() error {
+	if flusher, ok := f.w.(Flusher); ok {
+		return flusher.Flush()
+	}
+	return nil
+}
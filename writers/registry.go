@@ -0,0 +1,64 @@
+// Copyright 2014 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package writers
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Writer 是 io.Writer 的别名，用于第三方注册自定义 receiver 时使用，
+// 避免额外引入 io 包。
+type Writer = io.Writer
+
+// Factory 根据配置中的属性 attrs 和已经构建完成的子元素 children，
+// 构造出一个真正的 io.Writer 实例。children 按配置文件中出现的顺序排列，
+// 对于没有子元素的 receiver（如本身即为终端输出的 smtp），可忽略该参数。
+//
+// 若返回的 io.Writer 直接作为某个日志级别的输出且实现了 Flusher 接口，
+// 则在 Flush() 和重新加载配置时会自动调用其 Flush 方法；作为其它 receiver
+// 的子元素时，只有其父元素主动调用时才会被 Flush。若需要在重新加载配置
+// 时释放资源（如关闭文件句柄），应自行在 factory 内部处理，framework
+// 不会主动调用 Close。
+type Factory func(attrs map[string]string, children []Writer) (io.Writer, error)
+
+var registry = struct {
+	sync.RWMutex
+	m map[string]Factory
+}{m: make(map[string]Factory)}
+
+// Register 注册一个自定义的 receiver，name 对应 XML 配置文件中的元素名称。
+// 重复注册同一个 name 会覆盖之前的注册内容，方便应用在测试中替换实现。
+func Register(name string, factory Factory) {
+	registry.Lock()
+	defer registry.Unlock()
+	registry.m[name] = factory
+}
+
+// Lookup 查找 name 对应的 Factory，found 为 false 表示未注册。
+func Lookup(name string) (factory Factory, found bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	factory, found = registry.m[name]
+	return factory, found
+}
+
+func init() {
+	Register("buffer", func(attrs map[string]string, children []Writer) (io.Writer, error) {
+		if len(children) != 1 {
+			return nil, fmt.Errorf("buffer 只能有一个子元素")
+		}
+		return NewBuffer(children[0], attrs)
+	})
+
+	Register("smtp", func(attrs map[string]string, children []Writer) (io.Writer, error) {
+		return NewSMTP(attrs)
+	})
+
+	Register("rotate", func(attrs map[string]string, children []Writer) (io.Writer, error) {
+		return NewRotateFile(attrs)
+	})
+}
@@ -0,0 +1,242 @@
+// Copyright 2014 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package writers
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rotateSizeUnits 将配置文件中常见的单位换算成字节数，按单位长度从长到短
+// 排列，确保匹配 "MB"/"GB" 等多字符单位时不会被同样以 "B" 结尾的单位误判。
+var rotateSizeUnits = []struct {
+	unit string
+	mul  int64
+}{
+	{"KB", 1 << 10},
+	{"MB", 1 << 20},
+	{"GB", 1 << 30},
+	{"B", 1},
+}
+
+// parseRotateSize 解析诸如 "10MB" 之类的大小描述，返回对应的字节数。
+func parseRotateSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	for _, u := range rotateSizeUnits {
+		if strings.HasSuffix(s, u.unit) {
+			n, err := strconv.ParseInt(strings.TrimSuffix(s, u.unit), 10, 64)
+			if err != nil {
+				return 0, err
+			}
+			return n * u.mul, nil
+		}
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
+
+// RotateFile 是按大小和/或按天切分日志文件的 io.Writer 实现，
+// 旧文件通过重命名后重新打开新文件的方式完成切割，不影响已经
+// 打开旧文件进行读取的其它进程；超过 maxrolls 的旧文件会被自动清理，
+// 清理前可选择对其进行 gzip 压缩。
+type RotateFile struct {
+	mu       sync.Mutex
+	path     string
+	size     int64 // 单个文件的最大字节数，0 表示不按大小切分
+	daily    bool  // 是否按天（本地时间午夜）切分
+	maxrolls int   // 最多保留的历史文件数量，0 表示不清理
+	gzip     bool  // 切分之后是否对旧文件进行 gzip 压缩
+
+	file    *os.File
+	written int64
+	day     int // 当前文件所属的日期，由 dayOf 计算，只用于判断日期是否相同
+}
+
+// NewRotateFile 根据配置内容返回一个 RotateFile 实例。
+// attrs["path"] 为日志文件路径，必填；attrs["size"] 指定单文件大小上限，
+// 如 "10MB"；attrs["daily"] 为 "true" 时按天切分；attrs["maxrolls"]
+// 指定保留的历史文件数量；attrs["gzip"] 为 "true" 时压缩切分后的旧文件。
+func NewRotateFile(attrs map[string]string) (io.Writer, error) {
+	path := attrs["path"]
+	if len(path) == 0 {
+		return nil, fmt.Errorf("rotate 缺少必要的参数：path")
+	}
+
+	r := &RotateFile{path: path}
+
+	if s, found := attrs["size"]; found && len(s) > 0 {
+		size, err := parseRotateSize(s)
+		if err != nil {
+			return nil, fmt.Errorf("rotate 的 size 参数无效：%v", err)
+		}
+		r.size = size
+	}
+
+	r.daily = attrs["daily"] == "true"
+	r.gzip = attrs["gzip"] == "true"
+
+	if m, found := attrs["maxrolls"]; found && len(m) > 0 {
+		n, err := strconv.Atoi(m)
+		if err != nil {
+			return nil, fmt.Errorf("rotate 的 maxrolls 参数无效：%v", err)
+		}
+		r.maxrolls = n
+	}
+
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// dayOf 以本地时间计算 t 所属的日期，使按天切分以本地时间的午夜为界，
+// 而不是 UTC 的午夜。
+func dayOf(t time.Time) int {
+	t = t.Local()
+	year, month, day := t.Date()
+	return year*512 + int(month)*32 + day // 任意进制换算，只用于判断日期是否相同
+}
+
+func (r *RotateFile) open() error {
+	info, err := os.Stat(r.path)
+	var size int64
+	if err == nil {
+		size = info.Size()
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	r.file = f
+	r.written = size
+	r.day = dayOf(time.Now())
+	return nil
+}
+
+// needRotate 判断当前是否需要执行切分，调用方须持有 r.mu。
+func (r *RotateFile) needRotate(n int) bool {
+	if r.size > 0 && r.written+int64(n) > r.size {
+		return true
+	}
+	if r.daily && dayOf(time.Now()) != r.day {
+		return true
+	}
+	return false
+}
+
+// Write 实现 io.Writer 接口，必要时先完成一次切分。
+func (r *RotateFile) Write(bs []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.needRotate(len(bs)) {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(bs)
+	r.written += int64(n)
+	return n, err
+}
+
+// rotate 将当前文件重命名为带时间戳的历史文件，再打开一个全新的文件，
+// 之后根据 maxrolls 清理多余的历史文件。
+func (r *RotateFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+
+	rolled := fmt.Sprintf("%v.%v", r.path, time.Now().Format("20060102-150405.000000000"))
+	if err := os.Rename(r.path, rolled); err != nil {
+		return err
+	}
+
+	if r.gzip {
+		if err := gzipFile(rolled); err == nil {
+			os.Remove(rolled)
+			rolled += ".gz"
+		}
+	}
+
+	if err := r.open(); err != nil {
+		return err
+	}
+
+	return r.prune()
+}
+
+// prune 删除超出 maxrolls 数量的历史文件，仅保留最新的若干份。
+func (r *RotateFile) prune() error {
+	if r.maxrolls <= 0 {
+		return nil
+	}
+
+	dir := filepath.Dir(r.path)
+	base := filepath.Base(r.path)
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	var rolls []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if name != base && strings.HasPrefix(name, base+".") {
+			rolls = append(rolls, filepath.Join(dir, name))
+		}
+	}
+
+	if len(rolls) <= r.maxrolls {
+		return nil
+	}
+
+	sort.Strings(rolls) // 文件名中的时间戳保证了字典序即为时间序
+	for _, name := range rolls[:len(rolls)-r.maxrolls] {
+		os.Remove(name)
+	}
+
+	return nil
+}
+
+func gzipFile(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(path + ".gz")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gw := gzip.NewWriter(f)
+	if _, err := gw.Write(data); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+// Flush 实现 Flusher 接口，将操作系统层面的缓存同步到磁盘。
+func (r *RotateFile) Flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Sync()
+}
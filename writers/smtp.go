@@ -0,0 +1,62 @@
+// Copyright 2014 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package writers
+
+import (
+	"fmt"
+	"io"
+	"net/smtp"
+	"strings"
+)
+
+// smtpWriter 通过邮件的方式发送日志内容。
+type smtpWriter struct {
+	username string
+	password string
+	host     string
+	subject  string
+	sendTo   []string
+}
+
+// NewSMTP 根据配置内容，返回一个通过邮件发送日志内容的 io.Writer 实例。
+// 必须的属性有 username、password、host、sendTo，subject 可省略。
+func NewSMTP(attrs map[string]string) (io.Writer, error) {
+	w := &smtpWriter{
+		username: attrs["username"],
+		password: attrs["password"],
+		host:     attrs["host"],
+		subject:  attrs["subject"],
+	}
+
+	if len(attrs["sendTo"]) == 0 {
+		return nil, fmt.Errorf("smtp 缺少必要的参数：sendTo")
+	}
+	w.sendTo = strings.Split(attrs["sendTo"], ";")
+
+	if len(w.host) == 0 {
+		return nil, fmt.Errorf("smtp 缺少必要的参数：host")
+	}
+
+	if len(w.subject) == 0 {
+		w.subject = "发送该邮件仅为测试"
+	}
+
+	return w, nil
+}
+
+func (w *smtpWriter) Write(bs []byte) (int, error) {
+	host := w.host
+	if index := strings.IndexByte(host, ':'); index >= 0 {
+		host = host[:index]
+	}
+
+	auth := smtp.PlainAuth("", w.username, w.password, host)
+	content := fmt.Sprintf("To:%v\r\nFrom:%v\r\nSubject:%v\r\n\r\n%s", strings.Join(w.sendTo, ";"), w.username, w.subject, bs)
+
+	if err := smtp.SendMail(w.host, auth, w.username, w.sendTo, []byte(content)); err != nil {
+		return 0, err
+	}
+	return len(bs), nil
+}
@@ -0,0 +1,12 @@
+// Copyright 2014 by caixw, All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+// Package writers 提供了 logs 包所使用的各类 io.Writer 实现。
+package writers
+
+// Flusher 被那些带缓存功能的 io.Writer 实现，
+// 调用 Flush 可以强制将缓存中的内容输出。
+type Flusher interface {
+	Flush() error
+}